@@ -8,12 +8,17 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -21,7 +26,9 @@ import (
 var DefaultResponseHeaderTimeout = internal.Duration{Duration: 3 * time.Second}
 var DefaultClientTimeout = internal.Duration{Duration: 4 * time.Second}
 
-var serverInfos []serverInfo
+// DefaultMaxConcurrentRequests bounds how many servers are gathered from at
+// once when a plugin instance does not set max_concurrent_requests.
+const DefaultMaxConcurrentRequests = 5
 
 type serverInfo struct {
 	HostName string
@@ -30,6 +37,14 @@ type serverInfo struct {
 	UserName string
 	Password string
 	Metrics  []Metric
+
+	// Proxy-mode fields. When IsProxy is true, requests for this server are
+	// POSTed to the configured proxy agent (URI above is the proxy's
+	// address) and wrapped with a "target" object pointing at TargetURL.
+	IsProxy        bool
+	TargetURL      string
+	TargetUser     string
+	TargetPassword string
 }
 
 type Metric struct {
@@ -39,6 +54,37 @@ type Metric struct {
 	Attribute  string
 	Path       string
 	Tags       map[string]string
+
+	// Type selects the Jolokia operation performed for this metric:
+	// "read" (default), "exec", "search", "list", or "version".
+	Type string
+
+	// Operation and Arguments are only used when Type is "exec".
+	Operation string
+	Arguments []interface{}
+
+	// TagKeys and TagPrefix only apply when Mbean is a pattern (contains
+	// "*"): Jolokia then returns a map keyed by the matched MBeans'
+	// canonical object names (e.g. "java.lang:type=MemoryPool,name=Eden
+	// Space"). TagKeys names the object-name properties (e.g. "name",
+	// "type") to promote into tags, with TagPrefix prepended to the tag
+	// key; one metric is emitted per matched instance.
+	TagKeys   []string
+	TagPrefix string
+
+	// FieldName renames the extracted field when exactly one is produced
+	// (e.g. an attribute collected via Path); FieldPrefix is prepended to
+	// every extracted field name. FieldRename maps a regular expression to
+	// a replacement applied to each (possibly prefixed) field name.
+	// FieldType coerces a named field's value to "int", "uint", "float",
+	// "bool", or "string". IncludeFields/ExcludeFields are glob lists
+	// applied, in that order, after renaming.
+	FieldName     string            `toml:"field_name"`
+	FieldPrefix   string            `toml:"field_prefix"`
+	FieldRename   map[string]string `toml:"field_rename"`
+	FieldType     map[string]string `toml:"field_type"`
+	IncludeFields []string          `toml:"include_fields"`
+	ExcludeFields []string          `toml:"exclude_fields"`
 }
 
 type JolokiaClient interface {
@@ -58,11 +104,30 @@ type Jolokia2 struct {
 	Context   string
 	Servers   []string //HostName:AppName@IP:PORT@USERNAME:PWD
 	Metrics   []Metric
-	Proxy     []string
+	Proxy     []string //HostName:AppName@IP:PORT@USERNAME:PWD of the *target* JVMs, reached through ProxyURL
+	ProxyURL  string   //IP:PORT of the Jolokia agent acting as the proxy
 	Delimiter string
 
+	MaxConcurrentRequests int `toml:"max_concurrent_requests"`
+
+	// Scheme is "http" (default) or "https". TLS options below only apply
+	// when Scheme is "https".
+	Scheme string `toml:"scheme"`
+	tlsint.ClientConfig
+
+	BearerToken     string `toml:"bearer_token"`
+	BearerTokenFile string `toml:"bearer_token_file"`
+
 	ResponseHeaderTimeout internal.Duration `toml:"response_header_timeout"`
 	ClientTimeout         internal.Duration `toml:"client_timeout"`
+
+	// serverInfos holds this instance's parsed Servers/Proxy/Metrics, built
+	// once via buildServerInfos so that multiple [[inputs.jolokia2]]
+	// sections (and config reloads) never share state with each other.
+	serverInfos  []serverInfo
+	serverErrors []error
+	initServers  sync.Once
+	reportErrors sync.Once
 }
 
 const sampleConfig = `
@@ -73,7 +138,14 @@ const sampleConfig = `
 
   ## List of servers exposing jolokia read service
   Servers = HostName:AppName@IP:PORT@USERNAME:PWD
-  
+
+  ## Optional: instead of (or in addition to) Servers, reach target JVMs
+  ## through a Jolokia agent running in proxy mode. proxy_url is the
+  ## address of that proxy agent; Proxy lists the remote targets using the
+  ## same HostName:AppName@IP:PORT@USERNAME:PWD syntax as Servers.
+  # proxy_url = "127.0.0.1:8080"
+  # Proxy = HostName:AppName@IP:PORT@USERNAME:PWD
+
   ## Optional http timeouts
   ##
   ## response_header_timeout, if non-zero, specifies the amount of time to wait
@@ -84,6 +156,26 @@ const sampleConfig = `
   ## Includes connection time, any redirects, and reading the response body.
   # client_timeout = "4s"
 
+  ## Maximum number of servers gathered from concurrently. Requests to each
+  ## server in Servers/Proxy are issued in parallel, bounded by this value.
+  # max_concurrent_requests = 5
+
+  ## Use TLS, optionally with client certificates, to reach Jolokia
+  ## endpoints served over HTTPS (e.g. behind a sidecar proxy).
+  # scheme = "https"
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Bearer token authentication, as an alternative to per-server
+  ## USERNAME:PWD credentials. bearer_token_file is re-read on every
+  ## gather, so it tracks a rotating token (e.g. a Kubernetes service
+  ## account token) without requiring a restart.
+  # bearer_token = "abc123"
+  # bearer_token_file = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
   ## Attribute delimiter
   ##
   ## When multiple attributes are returned for a single
@@ -107,6 +199,34 @@ const sampleConfig = `
     name = "class_count"
     mbean  = "java.lang:type=ClassLoading"
     attribute = "LoadedClassCount,UnloadedClassCount,TotalLoadedClassCount"
+
+  ## type may be "read" (default), "exec", "search", "list", or "version".
+  [[inputs.jolokia.metrics]]
+    name = "gc_run"
+    type = "exec"
+    mbean  = "java.lang:type=Memory"
+    operation = "gc"
+
+  ## A wildcard mbean with tag_keys emits one metric per matched instance,
+  ## promoting the requested object name properties into tags instead of
+  ## flattening them into the field name.
+  [[inputs.jolokia.metrics]]
+    name = "memory_pool"
+    mbean  = "java.lang:type=MemoryPool,name=*"
+    tag_keys = ["name"]
+    tag_prefix = "pool_"
+
+  ## After the attribute tree is flattened into field names, field_prefix
+  ## and field_rename are applied to each name, include/exclude_fields then
+  ## filter the renamed fields, and field_type coerces the fields that remain.
+  [[inputs.jolokia.metrics]]
+    name = "heap_memory_usage_typed"
+    mbean  = "java.lang:type=Memory"
+    attribute = "HeapMemoryUsage"
+    field_prefix = "heap_"
+    field_rename = {"^heap_(used|max)$" = "heap_${1}_bytes"}
+    field_type = {"heap_used_bytes" = "int"}
+    exclude_fields = ["heap_init"]
 `
 
 func (j *Jolokia2) SampleConfig() string {
@@ -150,24 +270,69 @@ func (j *Jolokia2) doRequest(req *http.Request) ([]map[string]interface{}, error
 	return jsonOut, nil
 }
 
+// requestType returns the Jolokia request "type" for a metric, defaulting
+// to "read" when the metric does not specify one.
+func requestType(metric Metric) string {
+	if metric.Type == "" {
+		return "read"
+	}
+	return metric.Type
+}
+
 func (j *Jolokia2) prepareRequest(server serverInfo, metrics []Metric) (*http.Request, error) {
 	var jolokiaUrl *url.URL
 	context := j.Context // Usually "/jolokia/"
 	var bulkBodyContent []map[string]interface{}
 	for _, metric := range metrics {
 		// Create bodyContent
+		reqType := requestType(metric)
 		bodyContent := map[string]interface{}{
-			"type":  "read",
-			"mbean": metric.Mbean,
+			"type": reqType,
 		}
 
-		if metric.Attribute != "" {
-			bodyContent["attribute"] = metric.Attribute
+		switch reqType {
+		case "read":
+			bodyContent["mbean"] = metric.Mbean
+			if metric.Attribute != "" {
+				bodyContent["attribute"] = metric.Attribute
+				if metric.Path != "" {
+					bodyContent["path"] = metric.Path
+				}
+			}
+		case "exec":
+			bodyContent["mbean"] = metric.Mbean
+			bodyContent["operation"] = metric.Operation
+			if len(metric.Arguments) > 0 {
+				bodyContent["arguments"] = metric.Arguments
+			}
+		case "search":
+			bodyContent["mbean"] = metric.Mbean
+		case "list":
 			if metric.Path != "" {
 				bodyContent["path"] = metric.Path
 			}
+		case "version":
+			// no additional fields required
+		default:
+			return nil, fmt.Errorf("unsupported jolokia request type %q for metric %q", reqType, metric.Name)
+		}
+
+		if server.IsProxy {
+			target := map[string]interface{}{
+				"url": server.TargetURL,
+			}
+			if server.TargetUser != "" || server.TargetPassword != "" {
+				target["user"] = server.TargetUser
+				target["password"] = server.TargetPassword
+			}
+			bodyContent["target"] = target
 		}
-		serverUrl, err := url.Parse("http://" + server.URI + context)
+
+		scheme := j.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		serverUrl, err := url.Parse(scheme + "://" + server.URI + context)
 		if err != nil {
 			return nil, err
 		}
@@ -188,53 +353,107 @@ func (j *Jolokia2) prepareRequest(server serverInfo, metrics []Metric) (*http.Re
 
 	req.Header.Add("Content-type", "application/json")
 
+	if err := j.setBearerToken(req); err != nil {
+		return nil, err
+	}
+
 	return req, nil
 }
 
-func (j *Jolokia2) analysisURI(acc telegraf.Accumulator) {
-	if serverInfos == nil || len(serverInfos) == 0 {
-		for _, uri := range j.Servers {
-			infos := strings.Split(uri, "@")
-			if len(infos) < 2 {
-				acc.AddError(fmt.Errorf("E! Server [%s], skipping", uri))
-				continue
-			}
-			names := strings.Split(infos[0], ":")
-			if len(names) != 2 {
-				acc.AddError(fmt.Errorf("E! Server[HostName:AppName] [%s], skipping", infos[0]))
-				continue
-			}
-			url := strings.Split(infos[1], ":")
-			if len(url) != 2 || net.ParseIP(url[0]) == nil {
-				acc.AddError(fmt.Errorf("E! Server[Host:Port] [%s], skipping", infos[1]))
-				continue
-			}
-			if s, err := strconv.Atoi(url[1]); err != nil || s < 0 || s > 65535 {
-				acc.AddError(fmt.Errorf("E! Server[Host:Port] [%s], skipping", infos[1]))
-				continue
-			}
-			info := serverInfo{HostName: names[0], AppName: names[1], URI: infos[1]}
-			if len(infos) > 2 {
-				up := strings.Join(infos[2:], "@")
-				ups := strings.Split(up, ":")
-				info.UserName = ups[0]
-				if len(ups) > 1 {
-					info.Password = strings.Join(ups[1:], ":")
-				}
-			}
-			serverInfos = append(serverInfos, info)
+// setBearerToken adds an Authorization header from BearerToken or
+// BearerTokenFile, if either is configured. BearerTokenFile is re-read on
+// every call so that a rotating token (e.g. a Kubernetes service account
+// token) is always current.
+func (j *Jolokia2) setBearerToken(req *http.Request) error {
+	token := j.BearerToken
+	if token == "" && j.BearerTokenFile != "" {
+		b, err := ioutil.ReadFile(j.BearerTokenFile)
+		if err != nil {
+			return fmt.Errorf("unable to read bearer_token_file: %s", err)
 		}
-		for _, metric := range j.Metrics {
-			if metric.ServerName == nil || len(metric.ServerName) == 0 {
-				j.addMetric("", "", metric)
-			} else {
-				for _, serverName := range metric.ServerName {
-					si := strings.Split(serverName, "@")
-					if len(si) == 1 {
-						j.addMetric(si[0], "", metric)
-					} else {
-						j.addMetric(si[0], strings.Join(si[1:], "@"), metric)
-					}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// parseServerURI parses a "HostName:AppName@IP:PORT@USERNAME:PWD" entry, as
+// used by both Servers and Proxy, into a serverInfo. The caller fills in
+// any proxy-specific fields.
+func parseServerURI(uri string) (serverInfo, error) {
+	infos := strings.Split(uri, "@")
+	if len(infos) < 2 {
+		return serverInfo{}, fmt.Errorf("E! Server [%s], skipping", uri)
+	}
+	names := strings.Split(infos[0], ":")
+	if len(names) != 2 {
+		return serverInfo{}, fmt.Errorf("E! Server[HostName:AppName] [%s], skipping", infos[0])
+	}
+	hostPort := strings.Split(infos[1], ":")
+	if len(hostPort) != 2 || net.ParseIP(hostPort[0]) == nil {
+		return serverInfo{}, fmt.Errorf("E! Server[Host:Port] [%s], skipping", infos[1])
+	}
+	if s, err := strconv.Atoi(hostPort[1]); err != nil || s < 0 || s > 65535 {
+		return serverInfo{}, fmt.Errorf("E! Server[Host:Port] [%s], skipping", infos[1])
+	}
+	info := serverInfo{HostName: names[0], AppName: names[1], URI: infos[1]}
+	if len(infos) > 2 {
+		up := strings.Join(infos[2:], "@")
+		ups := strings.Split(up, ":")
+		info.UserName = ups[0]
+		if len(ups) > 1 {
+			info.Password = strings.Join(ups[1:], ":")
+		}
+	}
+	return info, nil
+}
+
+// Init parses Servers, Proxy, and Metrics into j.serverInfos exactly once,
+// so that repeated Gather calls (and config reloads that create a fresh
+// Jolokia2 instance) never share state with any other instance.
+func (j *Jolokia2) Init() error {
+	j.initServers.Do(j.buildServerInfos)
+	return nil
+}
+
+func (j *Jolokia2) buildServerInfos() {
+	for _, uri := range j.Servers {
+		info, err := parseServerURI(uri)
+		if err != nil {
+			j.serverErrors = append(j.serverErrors, err)
+			continue
+		}
+		j.serverInfos = append(j.serverInfos, info)
+	}
+	for _, uri := range j.Proxy {
+		target, err := parseServerURI(uri)
+		if err != nil {
+			j.serverErrors = append(j.serverErrors, err)
+			continue
+		}
+		info := serverInfo{
+			HostName:       target.HostName,
+			AppName:        target.AppName,
+			URI:            j.ProxyURL,
+			IsProxy:        true,
+			TargetURL:      "service:jmx:rmi:///jndi/rmi://" + target.URI + "/jmxrmi",
+			TargetUser:     target.UserName,
+			TargetPassword: target.Password,
+		}
+		j.serverInfos = append(j.serverInfos, info)
+	}
+	for _, metric := range j.Metrics {
+		if metric.ServerName == nil || len(metric.ServerName) == 0 {
+			j.addMetric("", "", metric)
+		} else {
+			for _, serverName := range metric.ServerName {
+				si := strings.Split(serverName, "@")
+				if len(si) == 1 {
+					j.addMetric(si[0], "", metric)
+				} else {
+					j.addMetric(si[0], strings.Join(si[1:], "@"), metric)
 				}
 			}
 		}
@@ -242,15 +461,15 @@ func (j *Jolokia2) analysisURI(acc telegraf.Accumulator) {
 }
 
 func (j *Jolokia2) addMetric(hostName, appName string, metric Metric) {
-	for i, serverInfo := range serverInfos {
+	for i, server := range j.serverInfos {
 		if hostName == "" && appName == "" {
-			serverInfos[i].Metrics = append(serverInfos[i].Metrics, metric)
-		} else if hostName == "" && appName != "" && serverInfo.AppName == appName {
-			serverInfos[i].Metrics = append(serverInfos[i].Metrics, metric)
-		} else if hostName != "" && appName == "" && serverInfo.HostName == hostName {
-			serverInfos[i].Metrics = append(serverInfos[i].Metrics, metric)
-		} else if hostName != "" && appName != "" && serverInfo.HostName == hostName && serverInfo.AppName == appName {
-			serverInfos[i].Metrics = append(serverInfos[i].Metrics, metric)
+			j.serverInfos[i].Metrics = append(j.serverInfos[i].Metrics, metric)
+		} else if hostName == "" && appName != "" && server.AppName == appName {
+			j.serverInfos[i].Metrics = append(j.serverInfos[i].Metrics, metric)
+		} else if hostName != "" && appName == "" && server.HostName == hostName {
+			j.serverInfos[i].Metrics = append(j.serverInfos[i].Metrics, metric)
+		} else if hostName != "" && appName != "" && server.HostName == hostName && server.AppName == appName {
+			j.serverInfos[i].Metrics = append(j.serverInfos[i].Metrics, metric)
 		}
 
 	}
@@ -271,63 +490,300 @@ func (j *Jolokia2) extractValues(measurement string, value interface{}, fields m
 	}
 }
 
-func (j *Jolokia2) Gather(acc telegraf.Accumulator) error {
-	if j.jClient == nil {
-		tr := &http.Transport{ResponseHeaderTimeout: j.ResponseHeaderTimeout.Duration}
-		j.jClient = &JolokiaClientImpl{&http.Client{
-			Transport: tr,
-			Timeout:   j.ClientTimeout.Duration,
-		}}
+// dispatchValue turns the "value" of a Jolokia response into fields,
+// taking into account that not every request type returns a map: "search"
+// returns a list of matched mbean names, "exec" may return a scalar or
+// nothing at all (void operations), and "read"/"list"/"version" return
+// maps that extractValues already knows how to flatten. Any other scalar,
+// such as a "read" whose path selects a single value, is stored under "value".
+func (j *Jolokia2) dispatchValue(metric Metric, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		j.extractValues("", v, fields)
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				names = append(names, s)
+			}
+		}
+		fields["result"] = strings.Join(names, ",")
+	case nil:
+		// void exec operations return a null value; there is nothing to record.
+	default:
+		fields["value"] = v
+	}
+}
+
+// coerceFieldType converts value to the requested field type, returning the
+// original value unchanged if it cannot be converted.
+func coerceFieldType(value interface{}, fieldType string) interface{} {
+	switch fieldType {
+	case "int":
+		switch v := value.(type) {
+		case float64:
+			return int64(v)
+		case bool:
+			if v {
+				return int64(1)
+			}
+			return int64(0)
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i
+			}
+		}
+	case "uint":
+		switch v := value.(type) {
+		case float64:
+			return uint64(v)
+		case string:
+			if i, err := strconv.ParseUint(v, 10, 64); err == nil {
+				return i
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return v
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return v
+		case float64:
+			return v != 0
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	case "string":
+		return fmt.Sprintf("%v", value)
+	}
+	return value
+}
+
+// applyFieldOptions renames, filters, and type-coerces the fields extracted
+// for metric, per its FieldPrefix/FieldName/FieldRename/FieldType/
+// IncludeFields/ExcludeFields settings.
+func (j *Jolokia2) applyFieldOptions(metric Metric, fields map[string]interface{}) (map[string]interface{}, error) {
+	var includeFilter, excludeFilter filter.Filter
+	var err error
+	if len(metric.IncludeFields) > 0 {
+		if includeFilter, err = filter.Compile(metric.IncludeFields); err != nil {
+			return nil, fmt.Errorf("invalid include_fields: %s", err)
+		}
+	}
+	if len(metric.ExcludeFields) > 0 {
+		if excludeFilter, err = filter.Compile(metric.ExcludeFields); err != nil {
+			return nil, fmt.Errorf("invalid exclude_fields: %s", err)
+		}
 	}
 
-	j.analysisURI(acc)
-	for _, server := range serverInfos {
-		tags := make(map[string]string)
-		tags["HostName"] = server.HostName
-		tags["AppName"] = server.AppName
-		tags["URI"] = server.URI
+	renamePatterns := make([]string, 0, len(metric.FieldRename))
+	for pattern := range metric.FieldRename {
+		renamePatterns = append(renamePatterns, pattern)
+	}
+	sort.Strings(renamePatterns)
 
-		req, err := j.prepareRequest(server, server.Metrics)
-		if err != nil {
-			acc.AddError(fmt.Errorf("unable to create request: %s", err))
+	result := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		newName := metric.FieldPrefix + name
+		if metric.FieldName != "" && len(fields) == 1 {
+			newName = metric.FieldName
+		}
+		// Patterns are applied in sorted order so that a field matching more
+		// than one pattern renames deterministically across gather cycles,
+		// rather than depending on Go's randomized map iteration order.
+		for _, pattern := range renamePatterns {
+			re, compileErr := regexp.Compile(pattern)
+			if compileErr != nil {
+				return nil, fmt.Errorf("invalid field_rename pattern %q: %s", pattern, compileErr)
+			}
+			newName = re.ReplaceAllString(newName, metric.FieldRename[pattern])
+		}
+
+		if includeFilter != nil && !includeFilter.Match(newName) {
 			continue
 		}
-		out, err := j.doRequest(req)
+		if excludeFilter != nil && excludeFilter.Match(newName) {
+			continue
+		}
+
+		if fieldType, ok := metric.FieldType[newName]; ok {
+			value = coerceFieldType(value, fieldType)
+		}
+		result[newName] = value
+	}
+	return result, nil
+}
+
+// isWildcardMbean reports whether metric's response must be expanded into
+// one metric per matched MBean instance, rather than flattened as usual.
+func isWildcardMbean(metric Metric) bool {
+	return strings.Contains(metric.Mbean, "*") && len(metric.TagKeys) > 0
+}
+
+// parseMBeanProperties splits a canonical MBean object name
+// ("domain:key1=val1,key2=val2") into its property map.
+func parseMBeanProperties(objectName string) map[string]string {
+	props := make(map[string]string)
+	parts := strings.SplitN(objectName, ":", 2)
+	if len(parts) != 2 {
+		return props
+	}
+	for _, kv := range strings.Split(parts[1], ",") {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) == 2 {
+			props[pair[0]] = pair[1]
+		}
+	}
+	return props
+}
+
+// gatherWildcardInstances emits one metric per MBean instance matched by a
+// wildcard Mbean pattern, promoting metric.TagKeys from each instance's
+// object name into tags instead of flattening them into the field name.
+func (j *Jolokia2) gatherWildcardInstances(acc telegraf.Accumulator, metric Metric, instances map[string]interface{}, baseTags map[string]string) {
+	for objectName, instanceValue := range instances {
+		props := parseMBeanProperties(objectName)
+
+		tags := make(map[string]string, len(baseTags)+len(metric.TagKeys))
+		for k, v := range baseTags {
+			tags[k] = v
+		}
+		for _, tagKey := range metric.TagKeys {
+			if v, ok := props[tagKey]; ok {
+				tags[metric.TagPrefix+tagKey] = v
+			}
+		}
+
+		fields := make(map[string]interface{})
+		j.extractValues("", instanceValue, fields)
+		fields, err := j.applyFieldOptions(metric, fields)
 		if err != nil {
-			acc.AddError(fmt.Errorf("error performing request: %s", err))
+			acc.AddError(err)
 			continue
 		}
+		acc.AddFields(metric.Name, fields, tags)
+	}
+}
+
+// gatherServer requests all of a single server's metrics and adds the
+// resulting fields to acc. It touches no state shared with other servers,
+// so it is safe to call concurrently for different servers.
+func (j *Jolokia2) gatherServer(acc telegraf.Accumulator, server serverInfo) {
+	tags := make(map[string]string)
+	tags["HostName"] = server.HostName
+	tags["AppName"] = server.AppName
+	tags["URI"] = server.URI
+
+	req, err := j.prepareRequest(server, server.Metrics)
+	if err != nil {
+		acc.AddError(fmt.Errorf("unable to create request: %s", err))
+		return
+	}
+	out, err := j.doRequest(req)
+	if err != nil {
+		acc.AddError(fmt.Errorf("error performing request: %s", err))
+		return
+	}
+
+	if len(out) != len(server.Metrics) {
+		acc.AddError(fmt.Errorf("did not receive the correct number of metrics in response. expected %d, received %d", len(server.Metrics), len(out)))
+		return
+	}
 
-		if len(out) != len(server.Metrics) {
-			acc.AddError(fmt.Errorf("did not receive the correct number of metrics in response. expected %d, received %d", len(server.Metrics), len(out)))
+	for i, resp := range out {
+		metric := server.Metrics[i]
+		if status, ok := resp["status"]; ok && status != float64(200) {
+			acc.AddError(fmt.Errorf("Not expected status value in response body (%s mbean=\"%s\" attribute=\"%s\"): %3.f",
+				server.URI, metric.Mbean, metric.Attribute, status))
+			continue
+		} else if !ok {
+			acc.AddError(fmt.Errorf("Missing status in response body"))
 			continue
 		}
 
-		for i, resp := range out {
-			fields := make(map[string]interface{})
-			if status, ok := resp["status"]; ok && status != float64(200) {
-				acc.AddError(fmt.Errorf("Not expected status value in response body (%s mbean=\"%s\" attribute=\"%s\"): %3.f",
-					server.URI, server.Metrics[i].Mbean, server.Metrics[i].Attribute, status))
-				continue
-			} else if !ok {
-				acc.AddError(fmt.Errorf("Missing status in response body"))
-				continue
+		metricTags := tags
+		if metric.Tags != nil {
+			metricTags = make(map[string]string, len(tags)+len(metric.Tags))
+			for key, val := range tags {
+				metricTags[key] = val
 			}
-
-			if values, ok := resp["value"]; ok {
-				j.extractValues("", values, fields)
-			} else {
-				acc.AddError(fmt.Errorf("Missing key 'value' in output response\n"))
+			for key, val := range metric.Tags {
+				metricTags[key] = val
 			}
+		}
 
-			if server.Metrics[i].Tags != nil {
-				for key, val := range server.Metrics[i].Tags {
-					tags[key] = val
-				}
-			}
-			acc.AddFields(server.Metrics[i].Name, fields, tags)
+		values, ok := resp["value"]
+		if !ok {
+			acc.AddError(fmt.Errorf("Missing key 'value' in output response\n"))
+			continue
+		}
+
+		if instances, ok := values.(map[string]interface{}); ok && isWildcardMbean(metric) {
+			j.gatherWildcardInstances(acc, metric, instances, metricTags)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		j.dispatchValue(metric, values, fields)
+		fields, err := j.applyFieldOptions(metric, fields)
+		if err != nil {
+			acc.AddError(err)
+			continue
 		}
+		acc.AddFields(metric.Name, fields, metricTags)
 	}
+}
+
+func (j *Jolokia2) Gather(acc telegraf.Accumulator) error {
+	if j.jClient == nil {
+		tlsCfg, err := j.ClientConfig.TLSConfig()
+		if err != nil {
+			return fmt.Errorf("unable to build TLS config: %s", err)
+		}
+		tr := &http.Transport{
+			ResponseHeaderTimeout: j.ResponseHeaderTimeout.Duration,
+			TLSClientConfig:       tlsCfg,
+		}
+		j.jClient = &JolokiaClientImpl{&http.Client{
+			Transport: tr,
+			Timeout:   j.ClientTimeout.Duration,
+		}}
+	}
+
+	j.initServers.Do(j.buildServerInfos)
+	j.reportErrors.Do(func() {
+		for _, err := range j.serverErrors {
+			acc.AddError(err)
+		}
+	})
+
+	maxConcurrentRequests := j.MaxConcurrentRequests
+	if maxConcurrentRequests <= 0 {
+		maxConcurrentRequests = DefaultMaxConcurrentRequests
+	}
+
+	sem := make(chan struct{}, maxConcurrentRequests)
+	var wg sync.WaitGroup
+	for _, server := range j.serverInfos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(server serverInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			j.gatherServer(acc, server)
+		}(server)
+	}
+	wg.Wait()
+
 	return nil
 }
 
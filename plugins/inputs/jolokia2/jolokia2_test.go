@@ -1,11 +1,14 @@
 package jolokia2
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -114,6 +117,38 @@ const validSingleValueJSON = `
   }
 ]`
 
+// validWildcardMemoryPoolJSON expands on validThreeLevelMultiValueJSON's
+// pattern-mbean shape with multiple matched memory pool instances.
+const validWildcardMemoryPoolJSON = `
+[
+  {
+    "request":{
+      "mbean":"java.lang:type=MemoryPool,name=*",
+      "type":"read"
+    },
+    "value":{
+      "java.lang:type=MemoryPool,name=Eden Space":{
+        "Usage":{
+          "init":1000,
+          "committed":1500,
+          "max":2000,
+          "used":500
+        }
+      },
+      "java.lang:type=MemoryPool,name=Survivor Space":{
+        "Usage":{
+          "init":100,
+          "committed":150,
+          "max":200,
+          "used":50
+        }
+      }
+    },
+    "timestamp":1446129191,
+    "status":200
+  }
+]`
+
 const invalidJSON = "I don't think this is JSON"
 
 const empty = ""
@@ -261,7 +296,8 @@ func TestHttp404(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(acc.Metrics))
-	assert.Contains(t, err.Error(), "has status code 404")
+	assert.Equal(t, 1, len(acc.Errors))
+	assert.Contains(t, acc.Errors[0].Error(), "has status code 404")
 }
 
 // Test that the proper values are ignored or collected
@@ -276,7 +312,342 @@ func TestHttpInvalidJson(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(acc.Metrics))
-	assert.Contains(t, err.Error(), "Error decoding JSON response")
+	assert.Equal(t, 1, len(acc.Errors))
+	assert.Contains(t, acc.Errors[0].Error(), "Error decoding JSON response")
+}
+
+// Test that a "read" request (the default) is built the same way it always has been.
+func TestPrepareRequestReadType(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	req, err := jolokia.prepareRequest(server, []Metric{HeapMetric})
+	assert.NoError(t, err)
+
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"type":"read"`)
+	assert.Contains(t, string(body), `"mbean":"java.lang:type=Memory"`)
+}
+
+// Test that an "exec" request carries the operation and arguments.
+func TestPrepareRequestExecType(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	metric := Metric{Name: "gc_run", Type: "exec", Mbean: "java.lang:type=Memory",
+		Operation: "gc", Arguments: []interface{}{}}
+	req, err := jolokia.prepareRequest(server, []Metric{metric})
+	assert.NoError(t, err)
+
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"operation":"gc"`)
+	assert.Contains(t, string(body), `"type":"exec"`)
+}
+
+// Test that "search", "list", and "version" requests omit fields that only
+// apply to "read"/"exec".
+func TestPrepareRequestSearchListVersionTypes(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	for _, tc := range []struct {
+		metric Metric
+		want   string
+	}{
+		{Metric{Name: "search", Type: "search", Mbean: "java.lang:type=*"}, `"type":"search"`},
+		{Metric{Name: "list", Type: "list"}, `"type":"list"`},
+		{Metric{Name: "version", Type: "version"}, `"type":"version"`},
+	} {
+		req, err := jolokia.prepareRequest(server, []Metric{tc.metric})
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), tc.want)
+	}
+}
+
+// Test that proxy-mode requests wrap the body with a "target" object and POST
+// to the proxy agent's own URI rather than the target JVM's.
+func TestPrepareRequestProxyMode(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	server := serverInfo{
+		HostName:       "ECS7",
+		AppName:        "ydh",
+		URI:            "10.0.0.1:8080",
+		IsProxy:        true,
+		TargetURL:      "service:jmx:rmi:///jndi/rmi://127.0.0.1:7016/jmxrmi",
+		TargetUser:     "jmx-user",
+		TargetPassword: "jmx-pass",
+	}
+
+	req, err := jolokia.prepareRequest(server, []Metric{HeapMetric})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:8080", req.URL.Host)
+
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"target":{"password":"jmx-pass","url":"service:jmx:rmi:///jndi/rmi://127.0.0.1:7016/jmxrmi","user":"jmx-user"}`)
+}
+
+// Test that dispatchValue flattens "read"/"list"/"version" maps, as before.
+func TestDispatchValueMap(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	fields := make(map[string]interface{})
+
+	var value interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"used":123,"max":456}`), &value))
+	jolokia.dispatchValue(Metric{Name: "heap", Type: "read"}, value, fields)
+
+	assert.Equal(t, map[string]interface{}{"used": 123.0, "max": 456.0}, fields)
+}
+
+// Test that dispatchValue turns a "search" response's mbean name list into a
+// single comma separated field.
+func TestDispatchValueSearch(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	fields := make(map[string]interface{})
+
+	var value interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`["java.lang:type=Memory","java.lang:type=Threading"]`), &value))
+	jolokia.dispatchValue(Metric{Name: "search", Type: "search"}, value, fields)
+
+	assert.Equal(t, map[string]interface{}{"result": "java.lang:type=Memory,java.lang:type=Threading"}, fields)
+}
+
+// Test that dispatchValue records a scalar "exec" result under "value", and
+// leaves fields empty for a void operation's null result.
+func TestDispatchValueExec(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+
+	scalarFields := make(map[string]interface{})
+	var scalarValue interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`42`), &scalarValue))
+	jolokia.dispatchValue(Metric{Name: "gc_run", Type: "exec"}, scalarValue, scalarFields)
+	assert.Equal(t, map[string]interface{}{"value": 42.0}, scalarFields)
+
+	voidFields := make(map[string]interface{})
+	var voidValue interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &voidValue))
+	jolokia.dispatchValue(Metric{Name: "gc_run", Type: "exec"}, voidValue, voidFields)
+	assert.Equal(t, map[string]interface{}{}, voidFields)
+}
+
+// Test that two plugin instances gathered concurrently do not bleed their
+// server/metric state into each other, now that serverInfos lives on the
+// receiver instead of in a package-level variable.
+func TestTwoInstancesNoSharedState(t *testing.T) {
+	jolokiaA := genJolokiaClientStub(validMultiValueJSON, 200,
+		[]string{"ECS7:ydh@127.0.0.1:7016"}, []Metric{HeapMetric})
+	jolokiaB := genJolokiaClientStub(validBulkResponseJSON, 200,
+		[]string{"ECS8:other@127.0.0.2:7017"}, []Metric{HeapMetric, NonHeapMetric})
+
+	var accA, accB testutil.Accumulator
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, accA.GatherError(jolokiaA.Gather))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, accB.GatherError(jolokiaB.Gather))
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 1, len(accA.Metrics))
+	assert.Equal(t, 2, len(accB.Metrics))
+	assert.Equal(t, "127.0.0.1:7016", accA.Metrics[0].Tags["URI"])
+	for _, m := range accB.Metrics {
+		assert.Equal(t, "127.0.0.2:7017", m.Tags["URI"])
+	}
+}
+
+// Test that a malformed Servers entry is only reported once, on the first
+// Gather, even though the good entries keep being gathered on every
+// subsequent interval.
+func TestBadServerEntryReportedOnce(t *testing.T) {
+	servers := []string{"ECS7:ydh@127.0.0.1:7016", "not-a-valid-entry"}
+	jolokia := genJolokiaClientStub(validMultiValueJSON, 200, servers, []Metric{HeapMetric})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, jolokia.Gather(&acc))
+	assert.Equal(t, 1, len(acc.Errors))
+
+	assert.NoError(t, jolokia.Gather(&acc))
+	assert.Equal(t, 1, len(acc.Errors))
+}
+
+// Test that setting max_concurrent_requests still gathers every server.
+func TestMaxConcurrentRequestsBoundsButCompletes(t *testing.T) {
+	jolokia := genJolokiaClientStub(validMultiValueJSON, 200, Servers, []Metric{HeapMetric})
+	jolokia.MaxConcurrentRequests = 1
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 1, len(acc.Metrics))
+}
+
+// Test that scheme = "https" is reflected in the request URL.
+func TestPrepareRequestHTTPSScheme(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	jolokia.Scheme = "https"
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	req, err := jolokia.prepareRequest(server, []Metric{HeapMetric})
+	assert.NoError(t, err)
+	assert.Equal(t, "https", req.URL.Scheme)
+}
+
+// Test that bearer_token sets the Authorization header.
+func TestPrepareRequestBearerToken(t *testing.T) {
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	jolokia.BearerToken = "tok123"
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	req, err := jolokia.prepareRequest(server, []Metric{HeapMetric})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer tok123", req.Header.Get("Authorization"))
+}
+
+// Test that bearer_token_file is read and trimmed on every request.
+func TestPrepareRequestBearerTokenFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "jolokia-bearer-token")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("tok-from-file\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	jolokia := genJolokiaClientStub(empty, 200, Servers, nil)
+	jolokia.BearerTokenFile = f.Name()
+	server := serverInfo{HostName: "ECS7", AppName: "ydh", URI: "127.0.0.1:7016"}
+
+	req, err := jolokia.prepareRequest(server, []Metric{HeapMetric})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer tok-from-file", req.Header.Get("Authorization"))
+}
+
+// Test that a wildcard mbean with tag_keys emits one metric per matched
+// instance, promoting "name" into a tag instead of the field name.
+func TestWildcardMbeanTagExtraction(t *testing.T) {
+	metric := Metric{Name: "memory_pool", Mbean: "java.lang:type=MemoryPool,name=*",
+		TagKeys: []string{"name"}}
+	jolokia := genJolokiaClientStub(validWildcardMemoryPoolJSON, 200, Servers, []Metric{metric})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 2, len(acc.Metrics))
+
+	seen := map[string]map[string]interface{}{}
+	for _, m := range acc.Metrics {
+		assert.Equal(t, "memory_pool", m.Measurement)
+		seen[m.Tags["name"]] = m.Fields
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"Usage_init": 1000.0, "Usage_committed": 1500.0, "Usage_max": 2000.0, "Usage_used": 500.0,
+	}, seen["Eden Space"])
+	assert.Equal(t, map[string]interface{}{
+		"Usage_init": 100.0, "Usage_committed": 150.0, "Usage_max": 200.0, "Usage_used": 50.0,
+	}, seen["Survivor Space"])
+}
+
+// Test that tag_prefix is applied to promoted tag keys.
+func TestWildcardMbeanTagPrefix(t *testing.T) {
+	metric := Metric{Name: "memory_pool", Mbean: "java.lang:type=MemoryPool,name=*",
+		TagKeys: []string{"name"}, TagPrefix: "pool_"}
+	jolokia := genJolokiaClientStub(validWildcardMemoryPoolJSON, 200, Servers, []Metric{metric})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 2, len(acc.Metrics))
+	for _, m := range acc.Metrics {
+		assert.NotEmpty(t, m.Tags["pool_name"])
+		assert.Empty(t, m.Tags["name"])
+	}
+}
+
+// Test that without tag_keys configured, wildcard mbeans keep the old
+// flatten-into-field-name behavior for backward compatibility.
+func TestWildcardMbeanWithoutTagKeysFlattens(t *testing.T) {
+	metric := Metric{Name: "memory_pool", Mbean: "java.lang:type=MemoryPool,name=*"}
+	jolokia := genJolokiaClientStub(validWildcardMemoryPoolJSON, 200, Servers, []Metric{metric})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 1, len(acc.Metrics))
+	assert.Contains(t, acc.Metrics[0].Fields, "java.lang:type=MemoryPool,name=Eden Space_Usage_used")
+}
+
+// Test field_rename and field_type against the existing bulk response fixture.
+func TestFieldRenameAndTypeCoercion(t *testing.T) {
+	heap := Metric{Name: "heap_memory_usage", Mbean: "java.lang:type=Memory", Attribute: "HeapMemoryUsage",
+		FieldRename: map[string]string{"^used$": "used_bytes"},
+		FieldType:   map[string]string{"used_bytes": "int"},
+	}
+	nonHeap := NonHeapMetric
+	jolokia := genJolokiaClientStub(validBulkResponseJSON, 200, Servers, []Metric{heap, nonHeap})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 2, len(acc.Metrics))
+
+	for _, m := range acc.Metrics {
+		if m.Measurement == heap.Name {
+			assert.Equal(t, int64(203288528), m.Fields["used_bytes"])
+			assert.NotContains(t, m.Fields, "used")
+		} else {
+			assert.Equal(t, 49944048.0, m.Fields["used"])
+		}
+	}
+}
+
+// Test that overlapping field_rename patterns are applied in a fixed
+// (sorted) order, so the result does not depend on Go's randomized map
+// iteration order.
+func TestFieldRenameDeterministicOrder(t *testing.T) {
+	// Patterns are applied in sorted-key order, so "^used$" (applied first)
+	// renames "used" to "xtemp" before "^xtemp$" (applied second) renames
+	// that result to "final". Picking keys whose sort order matches the
+	// intended application order lets this test also exercise chaining.
+	heap := Metric{Name: "heap_memory_usage", Mbean: "java.lang:type=Memory", Attribute: "HeapMemoryUsage",
+		FieldRename: map[string]string{"^used$": "xtemp", "^xtemp$": "final"},
+	}
+	jolokia := genJolokiaClientStub(validMultiValueJSON, 200, Servers, []Metric{heap})
+
+	for i := 0; i < 10; i++ {
+		var acc testutil.Accumulator
+		assert.NoError(t, acc.GatherError(jolokia.Gather))
+		assert.Equal(t, 1, len(acc.Metrics))
+		assert.Contains(t, acc.Metrics[0].Fields, "final")
+		assert.NotContains(t, acc.Metrics[0].Fields, "used")
+		assert.NotContains(t, acc.Metrics[0].Fields, "xtemp")
+	}
+}
+
+// Test exclude_fields/include_fields glob filtering from the multi-value response fixture.
+func TestFieldIncludeExcludeFields(t *testing.T) {
+	heap := Metric{Name: "heap_memory_usage", Mbean: "java.lang:type=Memory", Attribute: "HeapMemoryUsage",
+		ExcludeFields: []string{"max"},
+	}
+	jolokia := genJolokiaClientStub(validMultiValueJSON, 200, Servers, []Metric{heap})
+
+	var acc testutil.Accumulator
+	assert.NoError(t, acc.GatherError(jolokia.Gather))
+	assert.Equal(t, 1, len(acc.Metrics))
+	assert.NotContains(t, acc.Metrics[0].Fields, "max")
+	assert.Contains(t, acc.Metrics[0].Fields, "used")
+
+	include := Metric{Name: "heap_memory_usage", Mbean: "java.lang:type=Memory", Attribute: "HeapMemoryUsage",
+		IncludeFields: []string{"used"},
+	}
+	jolokiaInclude := genJolokiaClientStub(validMultiValueJSON, 200, Servers, []Metric{include})
+	var accInclude testutil.Accumulator
+	assert.NoError(t, accInclude.GatherError(jolokiaInclude.Gather))
+	assert.Equal(t, 1, len(accInclude.Metrics))
+	assert.Equal(t, map[string]interface{}{"used": 203288528.0}, accInclude.Metrics[0].Fields)
 }
 
 func AssertMutiContainsTaggedFields(